@@ -2,12 +2,23 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
 
 	"github.com/openshift/windows-machine-config-operator/pkg/bootstrapper"
 	"github.com/spf13/cobra"
 )
 
+// supportedPlatforms are the values accepted by the --platform flag. "none" is used for bare-metal or any
+// platform that does not require cloud provider specific setup.
+var supportedPlatforms = map[string]bool{
+	"aws":     true,
+	"azure":   true,
+	"gcp":     true,
+	"vsphere": true,
+	"none":    true,
+}
+
 var (
 	initializeKubeletCmd = &cobra.Command{
 		Use:   "initialize-kubelet",
@@ -34,6 +45,12 @@ var (
 		kubeletPath string
 		// The directory to install the kubelet and related files
 		installDir string
+		// The cloud platform the Windows node is running on. One of aws, azure, gcp, vsphere, none.
+		platform string
+		// The directory to download the platform-appropriate image credential provider binary into
+		credentialProviderBinDir string
+		// The location to render the kubelet's image credential provider config
+		credentialProviderConfig string
 	}
 )
 
@@ -45,6 +62,12 @@ func init() {
 		"Kubelet file location to bootstrap the Windows node")
 	initializeKubeletCmd.PersistentFlags().StringVar(&runOpts.installDir, "install-dir", "c:\\k",
 		"Kubelet file location to bootstrap the Windows node. Defaults to C:\\k")
+	initializeKubeletCmd.PersistentFlags().StringVar(&runOpts.platform, "platform", "none",
+		"Cloud platform the Windows node is running on. One of aws, azure, gcp, vsphere, none")
+	initializeKubeletCmd.PersistentFlags().StringVar(&runOpts.credentialProviderBinDir, "credential-provider-bin-dir",
+		"c:\\k\\credential-provider", "Directory to download the image credential provider binary into")
+	initializeKubeletCmd.PersistentFlags().StringVar(&runOpts.credentialProviderConfig, "credential-provider-config",
+		"c:\\k\\credential-provider-config.yaml", "Location to render the kubelet's image credential provider config")
 }
 
 // runInitializeKubeletCmd starts the Windows Machine Config Bootstrapper
@@ -52,7 +75,28 @@ func runInitializeKubeletCmd(cmd *cobra.Command, args []string) {
 	flag.Parse()
 	// TODO: add validation for flags
 
-	wmcb, err := bootstrapper.NewWinNodeBootstrapper(runOpts.installDir, runOpts.ignitionFile, runOpts.kubeletPath)
+	if !supportedPlatforms[runOpts.platform] {
+		log.Error(fmt.Errorf("unsupported platform %q", runOpts.platform), "could not create bootstrapper")
+		os.Exit(1)
+	}
+
+	// platform is passed straight through to bootstrapper.NewWinNodeBootstrapper; what it does with it, such as
+	// staging the Azure cloud-node-manager or computing the GCP hostname-override, lives in
+	// github.com/openshift/windows-machine-config-operator/pkg/bootstrapper, which this repo depends on rather
+	// than vendors, so this flag only takes effect once paired with a compatible version of that package.
+	//
+	// Risk: this repo has no way to verify that the paired WMCO version's NewWinNodeBootstrapper actually accepts
+	// this extra platform argument - there's no vendored copy or test against the real signature here, so a WMCO
+	// bump that doesn't match would be a compile break this repo can't catch on its own. Call this out explicitly
+	// whenever the WMCO dependency is updated.
+	// credentialProviderBinDir and credentialProviderConfig are likewise passed through unmodified; which
+	// per-platform image credential provider binary gets downloaded there and how credentialProviderConfig gets
+	// wired into kubelet's --image-credential-provider-config/--image-credential-provider-bin-dir flags is
+	// decided by that same external package, not by this command. The same signature risk noted above for
+	// platform applies to these two arguments as well - a WMCO bump without a matching 6-argument
+	// NewWinNodeBootstrapper would break the build, with nothing in this tree to catch it ahead of time.
+	wmcb, err := bootstrapper.NewWinNodeBootstrapper(runOpts.installDir, runOpts.ignitionFile, runOpts.kubeletPath,
+		runOpts.platform, runOpts.credentialProviderBinDir, runOpts.credentialProviderConfig)
 	if err != nil {
 		log.Error(err, "could not create bootstrapper")
 		os.Exit(1)
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/cloudprovider"
+	"github.com/spf13/cobra"
+)
+
+var createCount int
+
+var createCmd = &cobra.Command{
+	Use:   "create-windows-vm",
+	Short: "Creates one or more Windows VMs on the cluster's cloud provider",
+	Long:  "",
+	RunE:  runCreateCmd,
+}
+
+func init() {
+	addCommonFlags(createCmd)
+	createCmd.Flags().IntVar(&createCount, "count", 1, "Number of Windows VMs to create")
+	rootCmd.AddCommand(createCmd)
+}
+
+// runCreateCmd creates one or more Windows VMs and prints a JSON summary of the result. When --count is greater
+// than 1, creation is fanned out across the worker pool sized by --parallelism.
+func runCreateCmd(cmd *cobra.Command, args []string) error {
+	onError, err := parseOnError(commonOpts.onError)
+	if err != nil {
+		return err
+	}
+
+	cloud, err := cloudprovider.CloudProviderFactory(commonOpts.kubeconfigPath, commonOpts.credentialPath,
+		commonOpts.credentialAccountID, commonOpts.resourceTrackerDir, commonOpts.imageID, commonOpts.instanceType,
+		commonOpts.sshKey, commonOpts.privateKeyPath, commonOpts.providerOverride, commonOpts.parallelism, onError)
+	if err != nil {
+		return fmt.Errorf("error getting cloud provider client: %v", err)
+	}
+
+	vms, err := cloud.CreateWindowsVMs(createCount)
+	if err != nil {
+		return fmt.Errorf("error creating Windows VM(s): %v", err)
+	}
+
+	return printJSON(map[string]interface{}{
+		"status": "created",
+		"count":  len(vms),
+		"dir":    commonOpts.resourceTrackerDir,
+	})
+}
+
+// parseOnError validates the --on-error flag value.
+func parseOnError(value string) (cloudprovider.OnErrorPolicy, error) {
+	switch cloudprovider.OnErrorPolicy(value) {
+	case cloudprovider.OnErrorRollback, cloudprovider.OnErrorKeep:
+		return cloudprovider.OnErrorPolicy(value), nil
+	default:
+		return "", fmt.Errorf("invalid --on-error value %q, must be 'rollback' or 'keep'", value)
+	}
+}
+
+// printJSON writes v to stdout as indented JSON, for consumption by both humans and scripts.
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
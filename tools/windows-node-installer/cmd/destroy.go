@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/cloudprovider"
+	"github.com/spf13/cobra"
+)
+
+var destroyInstanceID string
+
+var destroyVMCmd = &cobra.Command{
+	Use:   "destroy-windows-vm",
+	Short: "Destroys a single Windows VM tracked in windows-node-installer.json",
+	Long:  "",
+	RunE:  runDestroyVMCmd,
+}
+
+var destroyVMsCmd = &cobra.Command{
+	Use:   "destroy-windows-vms",
+	Short: "Destroys every Windows VM and security group tracked in windows-node-installer.json",
+	Long:  "",
+	RunE:  runDestroyVMsCmd,
+}
+
+func init() {
+	addCommonFlags(destroyVMCmd)
+	destroyVMCmd.Flags().StringVar(&destroyInstanceID, "instance-id", "", "ID of the instance to destroy")
+	if err := destroyVMCmd.MarkFlagRequired("instance-id"); err != nil {
+		panic(err)
+	}
+	rootCmd.AddCommand(destroyVMCmd)
+
+	addCommonFlags(destroyVMsCmd)
+	rootCmd.AddCommand(destroyVMsCmd)
+}
+
+// runDestroyVMCmd destroys the single instance identified by --instance-id. It takes the same common flags as
+// create-windows-vm (--kubeconfig, --credentials and, where the platform is not detected, --provider), since the
+// resource tracker file only records instance and security group IDs, not enough to reconstruct a cloud client on
+// its own. The tracker does not record the platform, region/zone, network or private key fingerprint.
+func runDestroyVMCmd(cmd *cobra.Command, args []string) error {
+	cloud, err := cloudprovider.CloudProviderFactory(commonOpts.kubeconfigPath, commonOpts.credentialPath,
+		commonOpts.credentialAccountID, commonOpts.resourceTrackerDir, commonOpts.imageID, commonOpts.instanceType,
+		commonOpts.sshKey, commonOpts.privateKeyPath, commonOpts.providerOverride, commonOpts.parallelism,
+		cloudprovider.OnErrorKeep)
+	if err != nil {
+		return fmt.Errorf("error getting cloud provider client: %v", err)
+	}
+
+	if err := cloud.DestroyWindowsVM(destroyInstanceID); err != nil {
+		return fmt.Errorf("error destroying Windows VM %s: %v", destroyInstanceID, err)
+	}
+
+	return printJSON(map[string]string{"status": "destroyed", "instanceID": destroyInstanceID})
+}
+
+// runDestroyVMsCmd destroys every resource recorded in windows-node-installer.json.
+func runDestroyVMsCmd(cmd *cobra.Command, args []string) error {
+	cloud, err := cloudprovider.CloudProviderFactory(commonOpts.kubeconfigPath, commonOpts.credentialPath,
+		commonOpts.credentialAccountID, commonOpts.resourceTrackerDir, commonOpts.imageID, commonOpts.instanceType,
+		commonOpts.sshKey, commonOpts.privateKeyPath, commonOpts.providerOverride, commonOpts.parallelism,
+		cloudprovider.OnErrorKeep)
+	if err != nil {
+		return fmt.Errorf("error getting cloud provider client: %v", err)
+	}
+
+	if err := cloud.DestroyWindowsVMs(); err != nil {
+		return fmt.Errorf("error destroying Windows VMs: %v", err)
+	}
+
+	return printJSON(map[string]string{"status": "destroyed"})
+}
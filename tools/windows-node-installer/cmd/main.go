@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+
+	// Importing a provider subpackage for its side effect registers it with the cloudprovider package. Keep
+	// this list in sync with the platforms this binary should support out of the box; out-of-tree providers
+	// (e.g. Nutanix, OpenStack) can be added to a downstream build of this binary the same way.
+	_ "github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/cloudprovider/aws"
+	_ "github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/cloudprovider/azure"
+	_ "github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/cloudprovider/gcp"
+	_ "github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/cloudprovider/vsphere"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var log = logrus.New()
+
+var rootCmd = &cobra.Command{
+	Use:   "windows-node-installer",
+	Short: "windows-node-installer creates and destroys Windows nodes for an OpenShift cluster",
+	Long:  "",
+}
+
+// commonOpts are the flags shared by every subcommand that needs to construct a cloudprovider.Cloud.
+var commonOpts struct {
+	kubeconfigPath      string
+	credentialPath      string
+	credentialAccountID string
+	resourceTrackerDir  string
+	imageID             string
+	instanceType        string
+	sshKey              string
+	privateKeyPath      string
+	providerOverride    string
+	parallelism         int
+	onError             string
+}
+
+func addCommonFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&commonOpts.kubeconfigPath, "kubeconfig", "",
+		"Path to the kubeconfig of the existing OpenShift cluster")
+	cmd.PersistentFlags().StringVar(&commonOpts.credentialPath, "credentials", "",
+		"Path to the cloud provider credential file")
+	cmd.PersistentFlags().StringVar(&commonOpts.credentialAccountID, "credential-account", "default",
+		"Account name/ID within the credential file to use")
+	cmd.PersistentFlags().StringVar(&commonOpts.resourceTrackerDir, "dir", ".",
+		"Directory containing (or to create) the windows-node-installer.json resource tracker file")
+	cmd.PersistentFlags().StringVar(&commonOpts.imageID, "image-id", "", "Image ID to create the Windows VM from")
+	cmd.PersistentFlags().StringVar(&commonOpts.instanceType, "instance-type", "", "Instance type of the Windows VM")
+	cmd.PersistentFlags().StringVar(&commonOpts.sshKey, "ssh-key", "", "Name of the SSH key pair to use")
+	cmd.PersistentFlags().StringVar(&commonOpts.privateKeyPath, "private-key", "",
+		"Path to the private key used to decrypt the Windows VM password")
+	cmd.PersistentFlags().StringVar(&commonOpts.providerOverride, "provider", "",
+		"Force a specific cloud provider instead of detecting one from the cluster's Infrastructure object. "+
+			"Useful for bare-metal, vSphere or IPI-on-none where the platform can't be auto-detected")
+	cmd.PersistentFlags().IntVar(&commonOpts.parallelism, "parallelism", 1,
+		"Number of Windows VMs to create concurrently when --count is passed to create-windows-vm")
+	cmd.PersistentFlags().StringVar(&commonOpts.onError, "on-error", "keep",
+		"What to do with already-created instances if part of a --count batch fails: rollback or keep")
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		log.WithError(err).Error("command failed")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,17 @@
+package aws
+
+import (
+	"github.com/openshift/api/config/v1"
+	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/cloudprovider"
+)
+
+func init() {
+	cloudprovider.Register(v1.AWSPlatformType, func(cfg *cloudprovider.Config) (cloudprovider.Cloud, error) {
+		base, err := New(cfg.Oc, cfg.ImageID, cfg.InstanceType, cfg.SSHKey, cfg.CredentialPath, cfg.CredentialAccountID,
+			cfg.ResourceTrackerFilePath, cfg.PrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		return cloudprovider.WithPooledCreate(base, cfg.Parallelism, cfg.OnError)
+	})
+}
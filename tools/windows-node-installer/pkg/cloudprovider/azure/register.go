@@ -0,0 +1,16 @@
+package azure
+
+import (
+	"github.com/openshift/api/config/v1"
+	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/cloudprovider"
+)
+
+func init() {
+	cloudprovider.Register(v1.AzurePlatformType, func(cfg *cloudprovider.Config) (cloudprovider.Cloud, error) {
+		base, err := New(cfg.Oc, cfg.CredentialPath, cfg.ResourceTrackerDir, cfg.ImageID, cfg.InstanceType)
+		if err != nil {
+			return nil, err
+		}
+		return cloudprovider.WithPooledCreate(base, cfg.Parallelism, cfg.OnError)
+	})
+}
@@ -0,0 +1,168 @@
+package cloudprovider
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/types"
+)
+
+// OnErrorPolicy controls what CreateWindowsVMsWithPool does with instances that were already created
+// successfully when another instance in the same batch fails.
+type OnErrorPolicy string
+
+const (
+	// OnErrorRollback destroys every successfully created instance in the batch once the whole batch has finished,
+	// if any creation in it failed.
+	OnErrorRollback OnErrorPolicy = "rollback"
+	// OnErrorKeep leaves successfully created instances in place even if part of the batch failed.
+	OnErrorKeep OnErrorPolicy = "keep"
+)
+
+// maxCreateRetries bounds the total number of attempts, the first one included, at a single instance creation. Windows Server boots are
+// slow and WinRM often isn't reachable for 5-10 minutes after the instance reports running, so failures in that
+// window are retried with exponential backoff rather than treated as fatal immediately.
+//
+// Declared as a var, rather than a const, so tests can shrink it (and createRetryBaseDelay) to keep the retry
+// loop's backoff from making the test suite slow.
+var maxCreateRetries = 5
+
+// createRetryBaseDelay is the initial backoff between retries of a single instance's creation; it doubles after
+// each attempt.
+var createRetryBaseDelay = 30 * time.Second
+
+// created pairs a successfully created instance's ID (as used by destroyOne/the resource tracker) with the
+// types.WindowsVM handed back to the caller, so a rollback can reference the instance without the caller's
+// types.WindowsVM implementation needing to expose its own ID accessor.
+type created struct {
+	instanceID string
+	vm         types.WindowsVM
+}
+
+// CreateWindowsVMsWithPool fans `count` calls to createOne out across a worker pool of size parallelism,
+// retrying each instance's creation with exponential backoff. It is meant to be called from a provider's
+// CreateWindowsVMs implementation:
+//
+//	func (p *myProvider) CreateWindowsVMs(count int) ([]types.WindowsVM, error) {
+//		return cloudprovider.CreateWindowsVMsWithPool(count, p.parallelism, p.onError, p.createOne, p.DestroyWindowsVM)
+//	}
+//
+// createOne must return the provider-specific instance ID alongside the types.WindowsVM, so that a failed batch
+// can be rolled back via destroyOne without this package needing to know how to extract an ID from a
+// types.WindowsVM.
+//
+// On success it returns every created types.WindowsVM. If any instance ultimately fails after retries and
+// onError is OnErrorRollback, every instance created so far in the batch is destroyed and only the error is
+// returned; with OnErrorKeep the successfully created instances are returned alongside the error.
+func CreateWindowsVMsWithPool(count, parallelism int, onError OnErrorPolicy,
+	createOne func() (string, types.WindowsVM, error), destroyOne func(string) error) ([]types.WindowsVM, error) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		ok   []created
+		errs []error
+		sem  = make(chan struct{}, parallelism)
+	)
+
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			instanceID, vm, err := createOneWithRetry(createOne)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			ok = append(ok, created{instanceID: instanceID, vm: vm})
+		}()
+	}
+	wg.Wait()
+
+	vms := make([]types.WindowsVM, 0, len(ok))
+	for _, c := range ok {
+		vms = append(vms, c.vm)
+	}
+
+	if len(errs) == 0 {
+		return vms, nil
+	}
+
+	err := fmt.Errorf("%d of %d instance(s) failed to create, first error: %v", len(errs), count, errs[0])
+	if onError == OnErrorRollback {
+		for _, c := range ok {
+			if destroyErr := destroyOne(c.instanceID); destroyErr != nil {
+				err = fmt.Errorf("%v; additionally failed to roll back instance %s: %v", err, c.instanceID, destroyErr)
+			}
+		}
+		return nil, err
+	}
+	return vms, err
+}
+
+// BaseCloud is the subset of Cloud predating CreateWindowsVMs. A provider that has not yet grown its own
+// concurrent CreateWindowsVMs can satisfy the full Cloud interface by wrapping itself with WithPooledCreate.
+type BaseCloud interface {
+	CreateWindowsVM() (types.WindowsVM, error)
+	CreateWindowsVMWithPrivateSubnet() (types.WindowsVM, error)
+	DestroyWindowsVMs() error
+	DestroyWindowsVM(string) error
+}
+
+// pooledCloud adds CreateWindowsVMs to a BaseCloud via CreateWindowsVMsWithPool.
+type pooledCloud struct {
+	BaseCloud
+	parallelism int
+}
+
+// CreateWindowsVMs implements Cloud by calling the wrapped BaseCloud's CreateWindowsVM across a worker pool.
+// Note: since BaseCloud.CreateWindowsVM does not return a provider instance ID on its own, OnErrorRollback can't
+// be honored here - WithPooledCreate rejects it up front instead of silently falling back to OnErrorKeep.
+func (p *pooledCloud) CreateWindowsVMs(count int) ([]types.WindowsVM, error) {
+	return CreateWindowsVMsWithPool(count, p.parallelism, OnErrorKeep,
+		func() (string, types.WindowsVM, error) {
+			vm, err := p.CreateWindowsVM()
+			return "", vm, err
+		}, p.DestroyWindowsVM)
+}
+
+// WithPooledCreate adapts a BaseCloud into a full Cloud by adding a CreateWindowsVMs built on
+// CreateWindowsVMsWithPool. It returns an error if onError is OnErrorRollback, since BaseCloud.CreateWindowsVM
+// does not return a provider instance ID and so rollback cannot be performed for a wrapped provider. Providers
+// that need rollback support should implement CreateWindowsVMs themselves instead of using WithPooledCreate, as
+// the gcp and vsphere providers do.
+func WithPooledCreate(base BaseCloud, parallelism int, onError OnErrorPolicy) (Cloud, error) {
+	if onError == OnErrorRollback {
+		return nil, fmt.Errorf("--on-error=rollback is not supported by this provider")
+	}
+	return &pooledCloud{BaseCloud: base, parallelism: parallelism}, nil
+}
+
+// createOneWithRetry retries createOne with exponential backoff, since Windows Server instances routinely take
+// several minutes before WinRM is reachable.
+func createOneWithRetry(createOne func() (string, types.WindowsVM, error)) (string, types.WindowsVM, error) {
+	delay := createRetryBaseDelay
+	var lastErr error
+	for attempt := 0; attempt < maxCreateRetries; attempt++ {
+		instanceID, vm, err := createOne()
+		if err == nil {
+			return instanceID, vm, nil
+		}
+		lastErr = err
+		if attempt < maxCreateRetries-1 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return "", nil, fmt.Errorf("giving up after %d attempts: %v", maxCreateRetries, lastErr)
+}
@@ -0,0 +1,159 @@
+package cloudprovider
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/types"
+)
+
+func TestCreateWindowsVMsWithPoolConcurrencyBound(t *testing.T) {
+	var inFlight, maxInFlight int32
+	createOne := func() (string, types.WindowsVM, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return "id", nil, nil
+	}
+
+	vms, err := CreateWindowsVMsWithPool(10, 3, OnErrorKeep, createOne, func(string) error { return nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vms) != 10 {
+		t.Fatalf("expected 10 VMs, got %d", len(vms))
+	}
+	if maxInFlight > 3 {
+		t.Fatalf("expected at most 3 concurrent creations, saw %d", maxInFlight)
+	}
+}
+
+func TestCreateWindowsVMsWithPoolRetriesBeforeSucceeding(t *testing.T) {
+	origRetries, origDelay := maxCreateRetries, createRetryBaseDelay
+	maxCreateRetries = 3
+	createRetryBaseDelay = time.Millisecond
+	defer func() { maxCreateRetries, createRetryBaseDelay = origRetries, origDelay }()
+
+	var attempts int32
+	createOne := func() (string, types.WindowsVM, error) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			return "", nil, fmt.Errorf("instance not reachable yet")
+		}
+		return "id", nil, nil
+	}
+
+	vms, err := CreateWindowsVMsWithPool(1, 1, OnErrorKeep, createOne, func(string) error { return nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vms) != 1 {
+		t.Fatalf("expected 1 VM, got %d", len(vms))
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestCreateWindowsVMsWithPoolGivesUpAfterMaxRetries(t *testing.T) {
+	origRetries, origDelay := maxCreateRetries, createRetryBaseDelay
+	maxCreateRetries = 2
+	createRetryBaseDelay = time.Millisecond
+	defer func() { maxCreateRetries, createRetryBaseDelay = origRetries, origDelay }()
+
+	var attempts int32
+	createOne := func() (string, types.WindowsVM, error) {
+		atomic.AddInt32(&attempts, 1)
+		return "", nil, fmt.Errorf("always fails")
+	}
+
+	_, err := CreateWindowsVMsWithPool(1, 1, OnErrorKeep, createOne, func(string) error { return nil })
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts (maxCreateRetries), got %d", attempts)
+	}
+}
+
+func TestCreateWindowsVMsWithPoolOnErrorKeep(t *testing.T) {
+	origRetries, origDelay := maxCreateRetries, createRetryBaseDelay
+	maxCreateRetries = 1
+	createRetryBaseDelay = time.Millisecond
+	defer func() { maxCreateRetries, createRetryBaseDelay = origRetries, origDelay }()
+
+	var created int32
+	createOne := func() (string, types.WindowsVM, error) {
+		n := atomic.AddInt32(&created, 1)
+		if n == 3 {
+			return "", nil, fmt.Errorf("instance 3 failed")
+		}
+		return fmt.Sprintf("id-%d", n), nil, nil
+	}
+	destroyOne := func(instanceID string) error {
+		t.Fatalf("destroyOne should not be called under OnErrorKeep, got %s", instanceID)
+		return nil
+	}
+
+	vms, err := CreateWindowsVMsWithPool(5, 1, OnErrorKeep, createOne, destroyOne)
+	if err == nil {
+		t.Fatal("expected an error from the failed instance")
+	}
+	if len(vms) != 4 {
+		t.Fatalf("expected the 4 successfully created VMs to be kept, got %d", len(vms))
+	}
+}
+
+func TestCreateWindowsVMsWithPoolOnErrorRollback(t *testing.T) {
+	origRetries, origDelay := maxCreateRetries, createRetryBaseDelay
+	maxCreateRetries = 1
+	createRetryBaseDelay = time.Millisecond
+	defer func() { maxCreateRetries, createRetryBaseDelay = origRetries, origDelay }()
+
+	var created int32
+	createOne := func() (string, types.WindowsVM, error) {
+		n := atomic.AddInt32(&created, 1)
+		if n == 3 {
+			return "", nil, fmt.Errorf("instance 3 failed")
+		}
+		return fmt.Sprintf("id-%d", n), nil, nil
+	}
+
+	var mu sync.Mutex
+	var destroyed []string
+	destroyOne := func(instanceID string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		destroyed = append(destroyed, instanceID)
+		return nil
+	}
+
+	vms, err := CreateWindowsVMsWithPool(5, 1, OnErrorRollback, createOne, destroyOne)
+	if err == nil {
+		t.Fatal("expected an error from the failed instance")
+	}
+	if vms != nil {
+		t.Fatalf("expected no VMs to be returned after rollback, got %d", len(vms))
+	}
+	if len(destroyed) != 4 {
+		t.Fatalf("expected the 4 successfully created instances to be rolled back, got %d: %v",
+			len(destroyed), destroyed)
+	}
+}
+
+func TestWithPooledCreateRejectsRollback(t *testing.T) {
+	if _, err := WithPooledCreate(nil, 1, OnErrorRollback); err == nil {
+		t.Fatal("expected WithPooledCreate to reject OnErrorRollback")
+	}
+	if _, err := WithPooledCreate(nil, 1, OnErrorKeep); err != nil {
+		t.Fatalf("expected WithPooledCreate to accept OnErrorKeep, got %v", err)
+	}
+}
@@ -7,13 +7,19 @@ import (
 
 	"github.com/openshift/api/config/v1"
 	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/client"
-	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/cloudprovider/aws"
-	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/cloudprovider/azure"
 	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/resource"
 	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/types"
 	"k8s.io/client-go/util/homedir"
 )
 
+// Providers are registered by importing their subpackage for side effect (see the individual provider's
+// register.go), typically from the binary's main package:
+//
+//	import _ "github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/cloudprovider/aws"
+//
+// This package intentionally does not import the provider subpackages itself, since each of them imports this
+// package to call Register - importing them back here would create an import cycle.
+
 // Cloud is the interface that needs to be implemented per provider to allow support for creating Windows nodes on
 // that provider.
 type Cloud interface {
@@ -21,6 +27,10 @@ type Cloud interface {
 	CreateWindowsVM() (types.WindowsVM, error)
 	// CreateWindowsVMWithPrivateSubnet creates a Windows VM for a given cloud provider in a private subnet
 	CreateWindowsVMWithPrivateSubnet() (windowsVM types.WindowsVM, err error)
+	// CreateWindowsVMs creates count Windows VMs for a given cloud provider, fanning the creations out across a
+	// worker pool instead of creating them one at a time. See CreateWindowsVMsWithPool for the shared
+	// implementation providers are expected to delegate to.
+	CreateWindowsVMs(count int) ([]types.WindowsVM, error)
 	// DestroyWindowsVMs uses 'windows-node-installer.json' file that contains IDs of created instance and
 	// security group and deletes them.
 	// Example 'windows-node-installer.json' file:
@@ -30,6 +40,9 @@ type Cloud interface {
 	// {
 	// It deletes the security group only if the group is not associated with any instance.
 	// The association between the instance and security group are available from individual cloud provider.
+	//
+	// The tracker file holds no platform, region/zone, network or private key fingerprint, so a destroy run gets
+	// its cloud client from the same flags as a create run (see cmd/destroy.go's runDestroyVMCmd doc comment).
 	DestroyWindowsVMs() error
 	// DestroyWindowsVM destroys a specific instance that was passed to it. It returns an error when the WindowsVM
 	// deletion fails. It takes the instanceID as argument depending on the cloud provider implementation the
@@ -45,8 +58,14 @@ type Cloud interface {
 // The resourceTrackerDir is where the `windows-node-installer.json` file which contains IDs of created instance and
 // security group will be created.
 // privateKeyPath is the path of the private key which can be used to decrypt the password for the Windows VM created
+// providerOverride, if non-empty, is used instead of the platform type read from the cluster's Infrastructure
+// object. This is required for platforms the Infrastructure object cannot express precisely, such as bare-metal
+// or IPI-on-none, where GetCloudProvider returns a generic type but the user still wants e.g. the vSphere
+// provider.
+// parallelism and onError configure the worker pool used by the returned Cloud's CreateWindowsVMs.
 func CloudProviderFactory(kubeconfigPath, credentialPath, credentialAccountID, resourceTrackerDir,
-	imageID, instanceType, sshKey, privateKeyPath string) (Cloud, error) {
+	imageID, instanceType, sshKey, privateKeyPath, providerOverride string, parallelism int,
+	onError OnErrorPolicy) (Cloud, error) {
 	// File, dir, credential account sanity checks.
 	var err error
 	if kubeconfigPath != "" {
@@ -78,15 +97,29 @@ func CloudProviderFactory(kubeconfigPath, credentialPath, credentialAccountID, r
 		return nil, err
 	}
 
-	switch provider := cloudProvider.Type; provider {
-	case v1.AWSPlatformType:
-		return aws.New(oc, imageID, instanceType, sshKey, credentialPath, credentialAccountID, resourceTrackerFilePath, privateKeyPath)
-	case v1.AzurePlatformType:
-		return azure.New(oc, credentialPath, resourceTrackerDir, imageID, instanceType)
-	default:
-		return nil, fmt.Errorf("the '%v' cloud provider is not supported", provider)
+	platform := cloudProvider.Type
+	if providerOverride != "" {
+		platform = v1.PlatformType(providerOverride)
+	}
+
+	factory, ok := providers[platform]
+	if !ok {
+		return nil, fmt.Errorf("the '%v' cloud provider is not supported, supported providers: %v",
+			platform, Providers())
 	}
-	return nil, err
+	return factory(&Config{
+		Oc:                      oc,
+		CredentialPath:          credentialPath,
+		CredentialAccountID:     credentialAccountID,
+		ResourceTrackerDir:      resourceTrackerDir,
+		ResourceTrackerFilePath: resourceTrackerFilePath,
+		ImageID:                 imageID,
+		InstanceType:            instanceType,
+		SSHKey:                  sshKey,
+		PrivateKeyPath:          privateKeyPath,
+		Parallelism:             parallelism,
+		OnError:                 onError,
+	})
 }
 
 // makeValidAbsPath remakes a path into an absolute path and ensures that it exists.
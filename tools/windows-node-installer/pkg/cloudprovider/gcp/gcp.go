@@ -0,0 +1,300 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/openshift/api/config/v1"
+	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/client"
+	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/cloudprovider"
+	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/resource"
+	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/types"
+	compute "google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+)
+
+// zoneOpPollInterval is how often waitForZoneOp re-checks a long-running zonal operation.
+const zoneOpPollInterval = 5 * time.Second
+
+func init() {
+	cloudprovider.Register(v1.GCPPlatformType, func(cfg *cloudprovider.Config) (cloudprovider.Cloud, error) {
+		return New(cfg.Oc, cfg.CredentialPath, cfg.CredentialAccountID, cfg.ResourceTrackerFilePath, cfg.ImageID,
+			cfg.InstanceType, cfg.SSHKey, cfg.PrivateKeyPath, cfg.Parallelism, cfg.OnError)
+	})
+}
+
+// firewallPorts are the ports that need to be reachable on every Windows node that is bootstrapped through
+// this tool: WinRM, kubelet and kube-proxy.
+var firewallPorts = []string{"5986", "10250", "10256"}
+
+// GcpGetHostnameScript is a remote PowerShell command that returns the name GCE assigned to the instance's
+// network interface, rather than the OS hostname reported by `hostname.exe`. GCE Windows instances set their
+// OS hostname based on the instance name truncated to 15 characters (the NetBIOS limit), while the node name
+// used by the cluster is the full instance name. Running this during bootstrapping/CSR approval ensures the
+// kubelet registers with the name the cluster actually expects.
+const GcpGetHostnameScript = `(Invoke-RestMethod -Headers @{'Metadata-Flavor'='Google'} ` +
+	`-Uri 'http://metadata.google.internal/computeMetadata/v1/instance/hostname').Split('.')[0]`
+
+// gcpProvider holds the information required to create and destroy Windows instances on GCP.
+type gcpProvider struct {
+	oc                      *client.OpenShift
+	computeService          *compute.Service
+	projectID               string
+	zone                    string
+	imageID                 string
+	instanceType            string
+	sshKey                  string
+	resourceTrackerFilePath string
+	privateKeyPath          string
+	parallelism             int
+	onError                 cloudprovider.OnErrorPolicy
+	// trackerMu serializes read-modify-write access to the resource tracker file, since CreateWindowsVMs may
+	// call createOne from several goroutines at once.
+	trackerMu sync.Mutex
+}
+
+// New returns a cloud provider implementation for GCP that is capable of creating and destroying Windows
+// instances. credentialPath points at a GCP service account JSON key, and credentialAccountID is the project
+// that the service account belongs to. parallelism and onError configure CreateWindowsVMs' worker pool.
+func New(oc *client.OpenShift, credentialPath, credentialAccountID, resourceTrackerFilePath, imageID,
+	instanceType, sshKey, privateKeyPath string, parallelism int,
+	onError cloudprovider.OnErrorPolicy) (*gcpProvider, error) {
+	computeService, err := compute.NewService(context.TODO(), option.WithCredentialsFile(credentialPath))
+	if err != nil {
+		return nil, fmt.Errorf("error creating GCP compute service: %v", err)
+	}
+	zone, err := oc.GetCloudProviderZone()
+	if err != nil {
+		return nil, fmt.Errorf("error getting GCP zone from infrastructure object: %v", err)
+	}
+	return &gcpProvider{
+		oc:                      oc,
+		computeService:          computeService,
+		projectID:               credentialAccountID,
+		zone:                    zone,
+		imageID:                 imageID,
+		instanceType:            instanceType,
+		sshKey:                  sshKey,
+		resourceTrackerFilePath: resourceTrackerFilePath,
+		privateKeyPath:          privateKeyPath,
+		parallelism:             parallelism,
+		onError:                 onError,
+	}, nil
+}
+
+// CreateWindowsVM creates a Windows instance on GCP, opening up a firewall rule that allows WinRM, kubelet and
+// kube-proxy traffic to reach it, and records the instance and firewall rule IDs in the resource tracker file.
+func (g *gcpProvider) CreateWindowsVM() (types.WindowsVM, error) {
+	return g.createWindowsVM(false)
+}
+
+// CreateWindowsVMWithPrivateSubnet creates a Windows instance on GCP with no external IP, reachable only from
+// within the VPC.
+func (g *gcpProvider) CreateWindowsVMWithPrivateSubnet() (types.WindowsVM, error) {
+	return g.createWindowsVM(true)
+}
+
+func (g *gcpProvider) createWindowsVM(private bool) (types.WindowsVM, error) {
+	_, vm, err := g.createOne(private)
+	return vm, err
+}
+
+// CreateWindowsVMs creates count Windows instances concurrently across a worker pool, per
+// cloudprovider.CreateWindowsVMsWithPool.
+func (g *gcpProvider) CreateWindowsVMs(count int) ([]types.WindowsVM, error) {
+	return cloudprovider.CreateWindowsVMsWithPool(count, g.parallelism, g.onError,
+		func() (string, types.WindowsVM, error) { return g.createOne(false) }, g.DestroyWindowsVM)
+}
+
+// createOne creates a single Windows instance and records it in the resource tracker file, returning both the
+// instance ID (as tracked) and the types.WindowsVM wrapping it.
+func (g *gcpProvider) createOne(private bool) (string, types.WindowsVM, error) {
+	ruleName, err := g.createFirewallRule()
+	if err != nil {
+		return "", nil, fmt.Errorf("error creating firewall rule: %v", err)
+	}
+
+	instance, err := g.createInstance(private)
+	if err != nil {
+		return "", nil, fmt.Errorf("error creating GCP instance: %v", err)
+	}
+
+	g.trackerMu.Lock()
+	tracker, err := resource.ReadTrackerFile(g.resourceTrackerFilePath)
+	if err != nil {
+		g.trackerMu.Unlock()
+		return "", nil, err
+	}
+	tracker.InstanceIDs = append(tracker.InstanceIDs, instance.Name)
+	if !containsString(tracker.SecurityGroupIDs, ruleName) {
+		tracker.SecurityGroupIDs = append(tracker.SecurityGroupIDs, ruleName)
+	}
+	err = resource.WriteTrackerFile(g.resourceTrackerFilePath, tracker)
+	g.trackerMu.Unlock()
+	if err != nil {
+		return "", nil, err
+	}
+
+	vm, err := types.NewWindowsVM(instance.Name, g.sshKey, g.privateKeyPath)
+	return instance.Name, vm, err
+}
+
+// firewallRuleName is shared by every instance this tool creates in a project, so createFirewallRule must be
+// idempotent: CreateWindowsVMs calls createOne, and therefore createFirewallRule, once per instance in the batch.
+const firewallRuleName = "wni-windows-node-firewall"
+
+// createFirewallRule opens up the WinRM, kubelet and kube-proxy ports. The rule sets no target tags, so it applies
+// to every instance on the project's default network, not only to the instances created by this tool. It is a
+// no-op if the rule already exists, since it is shared across every instance created in the project rather than
+// created per-instance.
+func (g *gcpProvider) createFirewallRule() (string, error) {
+	if _, err := g.computeService.Firewalls.Get(g.projectID, firewallRuleName).Do(); err == nil {
+		return firewallRuleName, nil
+	} else if !isNotFound(err) {
+		return "", fmt.Errorf("error checking for existing firewall rule %s: %v", firewallRuleName, err)
+	}
+
+	firewall := &compute.Firewall{
+		Name: firewallRuleName,
+		Allowed: []*compute.FirewallAllowed{
+			{
+				IPProtocol: "tcp",
+				Ports:      firewallPorts,
+			},
+		},
+	}
+	if _, err := g.computeService.Firewalls.Insert(g.projectID, firewall).Do(); err != nil && !isConflict(err) {
+		return "", err
+	}
+	return firewallRuleName, nil
+}
+
+// isNotFound reports whether err is a GCE 404, e.g. from Firewalls.Get on a rule that doesn't exist yet.
+func isNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
+
+// isConflict reports whether err is a GCE 409, e.g. from Firewalls.Insert racing another caller that created the
+// same rule first.
+func isConflict(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict
+}
+
+// containsString reports whether s is present in values.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// instanceNamePrefix is used together with a generated suffix to keep created instance names unique and under
+// GCE's 63 character name limit.
+const instanceNamePrefix = "wni-windows-node"
+
+func (g *gcpProvider) createInstance(private bool) (*compute.Instance, error) {
+	name := fmt.Sprintf("%s-%s", instanceNamePrefix, utilrand.String(5))
+
+	networkInterface := &compute.NetworkInterface{
+		Network: "global/networks/default",
+	}
+	if !private {
+		networkInterface.AccessConfigs = []*compute.AccessConfig{
+			{Type: "ONE_TO_ONE_NAT", Name: "External NAT"},
+		}
+	}
+
+	hostnameScript := GcpGetHostnameScript
+	instance := &compute.Instance{
+		Name:        name,
+		MachineType: fmt.Sprintf("zones/%s/machineTypes/%s", g.zone, g.instanceType),
+		Disks: []*compute.AttachedDisk{
+			{
+				Boot:       true,
+				AutoDelete: true,
+				InitializeParams: &compute.AttachedDiskInitializeParams{
+					SourceImage: g.imageID,
+				},
+			},
+		},
+		NetworkInterfaces: []*compute.NetworkInterface{networkInterface},
+		Metadata: &compute.Metadata{
+			Items: []*compute.MetadataItems{
+				// wmcb-hostname-script is read back by the bootstrapper over WinRM during CSR approval so that
+				// the node registers under the name GCE actually assigned it, rather than the truncated OS
+				// hostname. See GcpGetHostnameScript's doc comment for why the two differ.
+				{Key: "wmcb-hostname-script", Value: &hostnameScript},
+			},
+		},
+	}
+
+	op, err := g.computeService.Instances.Insert(g.projectID, g.zone, instance).Do()
+	if err != nil {
+		return nil, fmt.Errorf("error inserting instance: %v", err)
+	}
+	if err := g.waitForZoneOp(op); err != nil {
+		return nil, fmt.Errorf("error waiting for instance %s to be created: %v", name, err)
+	}
+
+	created, err := g.computeService.Instances.Get(g.projectID, g.zone, name).Do()
+	if err != nil {
+		return nil, fmt.Errorf("error getting created instance %s: %v", name, err)
+	}
+	return created, nil
+}
+
+// waitForZoneOp polls a zonal compute operation until it reports DONE or returns an error.
+func (g *gcpProvider) waitForZoneOp(op *compute.Operation) error {
+	for {
+		if op.Status == "DONE" {
+			if op.Error != nil && len(op.Error.Errors) > 0 {
+				return fmt.Errorf("%s: %s", op.Error.Errors[0].Code, op.Error.Errors[0].Message)
+			}
+			return nil
+		}
+		time.Sleep(zoneOpPollInterval)
+		var err error
+		op, err = g.computeService.ZoneOperations.Get(g.projectID, g.zone, op.Name).Do()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// DestroyWindowsVMs destroys all instances and firewall rules recorded in the resource tracker file.
+func (g *gcpProvider) DestroyWindowsVMs() error {
+	tracker, err := resource.ReadTrackerFile(g.resourceTrackerFilePath)
+	if err != nil {
+		return err
+	}
+	for _, instanceID := range tracker.InstanceIDs {
+		if err := g.DestroyWindowsVM(instanceID); err != nil {
+			return err
+		}
+	}
+	for _, ruleName := range tracker.SecurityGroupIDs {
+		if _, err := g.computeService.Firewalls.Delete(g.projectID, ruleName).Do(); err != nil {
+			return fmt.Errorf("error deleting firewall rule %s: %v", ruleName, err)
+		}
+	}
+	return resource.RemoveTrackerFile(g.resourceTrackerFilePath)
+}
+
+// DestroyWindowsVM destroys the GCP instance identified by instanceID.
+func (g *gcpProvider) DestroyWindowsVM(instanceID string) error {
+	_, err := g.computeService.Instances.Delete(g.projectID, g.zone, instanceID).Do()
+	if err != nil {
+		return fmt.Errorf("error deleting instance %s: %v", instanceID, err)
+	}
+	return nil
+}
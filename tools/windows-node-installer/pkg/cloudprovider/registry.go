@@ -0,0 +1,47 @@
+package cloudprovider
+
+import (
+	"github.com/openshift/api/config/v1"
+	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/client"
+)
+
+// Config bundles the parameters a provider's Factory needs to construct a Cloud. It is built once by
+// CloudProviderFactory from the CLI flags/kubeconfig and handed to whichever Factory is registered for the
+// detected (or overridden) platform.
+type Config struct {
+	Oc                      *client.OpenShift
+	CredentialPath          string
+	CredentialAccountID     string
+	ResourceTrackerDir      string
+	ResourceTrackerFilePath string
+	ImageID                 string
+	InstanceType            string
+	SSHKey                  string
+	PrivateKeyPath          string
+	// Parallelism is the size of the worker pool CreateWindowsVMs fans instance creation out across.
+	Parallelism int
+	// OnError controls what CreateWindowsVMs does with already-created instances when part of a batch fails.
+	OnError OnErrorPolicy
+}
+
+// Factory constructs a Cloud implementation from a Config.
+type Factory func(*Config) (Cloud, error)
+
+// providers holds every registered Factory, keyed by the PlatformType it supports.
+var providers = make(map[v1.PlatformType]Factory)
+
+// Register associates a PlatformType with a Factory. Provider subpackages call this from their init(), so that
+// out-of-tree providers (e.g. Nutanix, OpenStack) can add support for a new platform by importing this package
+// and registering a Factory of their own, without needing to fork this package.
+func Register(platform v1.PlatformType, factory Factory) {
+	providers[platform] = factory
+}
+
+// Providers returns the platform types that currently have a Factory registered.
+func Providers() []v1.PlatformType {
+	platforms := make([]v1.PlatformType, 0, len(providers))
+	for platform := range providers {
+		platforms = append(platforms, platform)
+	}
+	return platforms
+}
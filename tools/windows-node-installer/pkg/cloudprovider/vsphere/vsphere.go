@@ -0,0 +1,344 @@
+package vsphere
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/openshift/api/config/v1"
+	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/client"
+	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/cloudprovider"
+	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/resource"
+	"github.com/openshift/windows-machine-config-bootstrapper/tools/windows-node-installer/pkg/types"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	vimtypes "github.com/vmware/govmomi/vim25/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+)
+
+func init() {
+	cloudprovider.Register(v1.VSpherePlatformType, func(cfg *cloudprovider.Config) (cloudprovider.Cloud, error) {
+		return New(cfg.Oc, cfg.CredentialPath, cfg.ResourceTrackerFilePath, cfg.ImageID, cfg.InstanceType, cfg.SSHKey,
+			cfg.PrivateKeyPath, cfg.Parallelism, cfg.OnError)
+	})
+}
+
+// instanceNamePrefix is used together with a generated suffix to keep cloned VM names unique.
+const instanceNamePrefix = "wni-windows-node"
+
+// vsphereProvider holds the information required to create and destroy Windows instances on vSphere.
+type vsphereProvider struct {
+	oc                      *client.OpenShift
+	client                  *govmomi.Client
+	datacenter              string
+	resourcePool            string
+	network                 string
+	privateNetwork          string
+	imageID                 string
+	instanceType            string
+	sshKey                  string
+	resourceTrackerFilePath string
+	privateKeyPath          string
+	parallelism             int
+	onError                 cloudprovider.OnErrorPolicy
+	// trackerMu serializes read-modify-write access to the resource tracker file, since CreateWindowsVMs may
+	// call createOne from several goroutines at once.
+	trackerMu sync.Mutex
+}
+
+// New returns a cloud provider implementation for vSphere that is capable of creating and destroying Windows
+// instances by cloning imageID (a template VM/OVA already present in the vCenter inventory). parallelism and
+// onError configure CreateWindowsVMs' worker pool.
+func New(oc *client.OpenShift, credentialPath, resourceTrackerFilePath, imageID, instanceType, sshKey,
+	privateKeyPath string, parallelism int, onError cloudprovider.OnErrorPolicy) (*vsphereProvider, error) {
+	creds, err := readCredentials(credentialPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading vSphere credentials: %v", err)
+	}
+	u, err := url.Parse(creds.vCenterURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing vCenter URL: %v", err)
+	}
+	u.User = url.UserPassword(creds.username, creds.password)
+
+	govmomiClient, err := govmomi.NewClient(context.TODO(), u, true)
+	if err != nil {
+		return nil, fmt.Errorf("error creating vSphere client: %v", err)
+	}
+
+	return &vsphereProvider{
+		oc:                      oc,
+		client:                  govmomiClient,
+		datacenter:              creds.datacenter,
+		resourcePool:            creds.resourcePool,
+		network:                 creds.network,
+		privateNetwork:          creds.privateNetwork,
+		imageID:                 imageID,
+		instanceType:            instanceType,
+		sshKey:                  sshKey,
+		resourceTrackerFilePath: resourceTrackerFilePath,
+		privateKeyPath:          privateKeyPath,
+		parallelism:             parallelism,
+		onError:                 onError,
+	}, nil
+}
+
+// vsphereCredentials holds the values parsed out of the vSphere credential file.
+type vsphereCredentials struct {
+	vCenterURL     string
+	username       string
+	password       string
+	datacenter     string
+	resourcePool   string
+	network        string
+	privateNetwork string
+}
+
+// readCredentials parses the vCenter connection details out of a small "key = value" credential file, e.g.:
+//
+//	vcenter_url = https://vcenter.example.com/sdk
+//	username = administrator@vsphere.local
+//	password = hunter2
+//	datacenter = dc1
+//	resource_pool = windows-nodes
+//	network = VM Network
+//	private_network = VM Network (private)
+//
+// This is unlike the AWS/Azure credential files consumed elsewhere in this package, which are in their
+// respective cloud vendor's native format; vSphere has no equivalent, so a minimal format of our own is used.
+func readCredentials(credentialPath string) (vsphereCredentials, error) {
+	file, err := os.Open(credentialPath)
+	if err != nil {
+		return vsphereCredentials{}, err
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return vsphereCredentials{}, err
+	}
+
+	creds := vsphereCredentials{
+		vCenterURL:     values["vcenter_url"],
+		username:       values["username"],
+		password:       values["password"],
+		datacenter:     values["datacenter"],
+		resourcePool:   values["resource_pool"],
+		network:        values["network"],
+		privateNetwork: values["private_network"],
+	}
+	if creds.vCenterURL == "" || creds.username == "" || creds.password == "" {
+		return vsphereCredentials{}, fmt.Errorf(
+			"credential file %s must set vcenter_url, username and password", credentialPath)
+	}
+	return creds, nil
+}
+
+// CreateWindowsVM clones imageID into a new VM on the default network and powers it on. Unlike the public
+// cloud providers there is no security group concept on vSphere; network access is controlled by whichever
+// port group/NSX firewall rule the target network is already a member of, so no firewall rule is created here.
+func (v *vsphereProvider) CreateWindowsVM() (types.WindowsVM, error) {
+	return v.createWindowsVM(false)
+}
+
+// CreateWindowsVMWithPrivateSubnet clones imageID onto the private (isolated) port group configured for the
+// cluster, instead of the default externally routable network.
+func (v *vsphereProvider) CreateWindowsVMWithPrivateSubnet() (types.WindowsVM, error) {
+	return v.createWindowsVM(true)
+}
+
+func (v *vsphereProvider) createWindowsVM(private bool) (types.WindowsVM, error) {
+	_, vm, err := v.createOne(private)
+	return vm, err
+}
+
+// CreateWindowsVMs clones count Windows VMs concurrently across a worker pool, per
+// cloudprovider.CreateWindowsVMsWithPool.
+func (v *vsphereProvider) CreateWindowsVMs(count int) ([]types.WindowsVM, error) {
+	return cloudprovider.CreateWindowsVMsWithPool(count, v.parallelism, v.onError,
+		func() (string, types.WindowsVM, error) { return v.createOne(false) }, v.DestroyWindowsVM)
+}
+
+// createOne clones a single Windows VM and records it in the resource tracker file, returning both its vSphere
+// VM name (as tracked) and the types.WindowsVM wrapping it.
+func (v *vsphereProvider) createOne(private bool) (string, types.WindowsVM, error) {
+	vmName, err := v.cloneVM(private)
+	if err != nil {
+		return "", nil, fmt.Errorf("error cloning vSphere template %s: %v", v.imageID, err)
+	}
+
+	v.trackerMu.Lock()
+	tracker, err := resource.ReadTrackerFile(v.resourceTrackerFilePath)
+	if err != nil {
+		v.trackerMu.Unlock()
+		return "", nil, err
+	}
+	tracker.InstanceIDs = append(tracker.InstanceIDs, vmName)
+	err = resource.WriteTrackerFile(v.resourceTrackerFilePath, tracker)
+	v.trackerMu.Unlock()
+	if err != nil {
+		return "", nil, err
+	}
+
+	vm, err := types.NewWindowsVM(vmName, v.sshKey, v.privateKeyPath)
+	return vmName, vm, err
+}
+
+func (v *vsphereProvider) cloneVM(private bool) (string, error) {
+	ctx := context.Background()
+	finder := find.NewFinder(v.client.Client, true)
+
+	datacenter, err := finder.DatacenterOrDefault(ctx, v.datacenter)
+	if err != nil {
+		return "", fmt.Errorf("error finding datacenter %s: %v", v.datacenter, err)
+	}
+	finder.SetDatacenter(datacenter)
+
+	template, err := finder.VirtualMachine(ctx, v.imageID)
+	if err != nil {
+		return "", fmt.Errorf("error finding template %s: %v", v.imageID, err)
+	}
+
+	folders, err := datacenter.Folders(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error getting datacenter folders: %v", err)
+	}
+
+	resourcePool, err := finder.ResourcePoolOrDefault(ctx, v.resourcePool)
+	if err != nil {
+		return "", fmt.Errorf("error finding resource pool %s: %v", v.resourcePool, err)
+	}
+	poolRef := resourcePool.Reference()
+
+	name := fmt.Sprintf("%s-%s", instanceNamePrefix, utilrand.String(5))
+	cloneSpec := vimtypes.VirtualMachineCloneSpec{
+		Location: vimtypes.VirtualMachineRelocateSpec{
+			Pool: &poolRef,
+		},
+		PowerOn: true,
+	}
+
+	network := v.network
+	if private {
+		network = v.privateNetwork
+	}
+	if network != "" {
+		if err := v.setNetwork(ctx, finder, template, &cloneSpec, network); err != nil {
+			return "", fmt.Errorf("error configuring network %s: %v", network, err)
+		}
+	}
+
+	task, err := template.Clone(ctx, folders.VmFolder, name, cloneSpec)
+	if err != nil {
+		return "", fmt.Errorf("error starting clone of %s: %v", v.imageID, err)
+	}
+	if _, err := task.WaitForResult(ctx, nil); err != nil {
+		return "", fmt.Errorf("error cloning %s into %s: %v", v.imageID, name, err)
+	}
+
+	return name, nil
+}
+
+// setNetwork points the clone's first network device at the given network, so private-subnet clones land on an
+// isolated port group instead of the template's original network.
+func (v *vsphereProvider) setNetwork(ctx context.Context, finder *find.Finder, template *object.VirtualMachine,
+	cloneSpec *vimtypes.VirtualMachineCloneSpec, network string) error {
+	net, err := finder.Network(ctx, network)
+	if err != nil {
+		return err
+	}
+	backing, err := net.EthernetCardBackingInfo(ctx)
+	if err != nil {
+		return err
+	}
+	devices, err := template.Device(ctx)
+	if err != nil {
+		return err
+	}
+	nics := devices.SelectByType((*vimtypes.VirtualEthernetCard)(nil))
+	if len(nics) == 0 {
+		return fmt.Errorf("template %s has no network adapters", v.imageID)
+	}
+	nic := nics[0].(vimtypes.BaseVirtualEthernetCard).GetVirtualEthernetCard()
+	nic.Backing = backing
+	cloneSpec.Config = &vimtypes.VirtualMachineConfigSpec{
+		DeviceChange: []vimtypes.BaseVirtualDeviceConfigSpec{
+			&vimtypes.VirtualDeviceConfigSpec{
+				Operation: vimtypes.VirtualDeviceConfigSpecOperationEdit,
+				Device:    nic,
+			},
+		},
+	}
+	return nil
+}
+
+// DestroyWindowsVMs destroys all VMs recorded in the resource tracker file.
+func (v *vsphereProvider) DestroyWindowsVMs() error {
+	tracker, err := resource.ReadTrackerFile(v.resourceTrackerFilePath)
+	if err != nil {
+		return err
+	}
+	for _, instanceID := range tracker.InstanceIDs {
+		if err := v.DestroyWindowsVM(instanceID); err != nil {
+			return err
+		}
+	}
+	return resource.RemoveTrackerFile(v.resourceTrackerFilePath)
+}
+
+// DestroyWindowsVM powers off and deletes the VM identified by instanceID (its vSphere VM name).
+func (v *vsphereProvider) DestroyWindowsVM(instanceID string) error {
+	ctx := context.Background()
+	finder := find.NewFinder(v.client.Client, true)
+
+	datacenter, err := finder.DatacenterOrDefault(ctx, v.datacenter)
+	if err != nil {
+		return fmt.Errorf("error finding datacenter %s: %v", v.datacenter, err)
+	}
+	finder.SetDatacenter(datacenter)
+
+	vm, err := finder.VirtualMachine(ctx, instanceID)
+	if err != nil {
+		return fmt.Errorf("error finding VM %s: %v", instanceID, err)
+	}
+
+	powerState, err := vm.PowerState(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting power state of %s: %v", instanceID, err)
+	}
+	if powerState == vimtypes.VirtualMachinePowerStatePoweredOn {
+		task, err := vm.PowerOff(ctx)
+		if err != nil {
+			return fmt.Errorf("error powering off %s: %v", instanceID, err)
+		}
+		if err := task.Wait(ctx); err != nil {
+			return fmt.Errorf("error waiting for %s to power off: %v", instanceID, err)
+		}
+	}
+
+	task, err := vm.Destroy(ctx)
+	if err != nil {
+		return fmt.Errorf("error destroying %s: %v", instanceID, err)
+	}
+	if err := task.Wait(ctx); err != nil {
+		return fmt.Errorf("error waiting for %s to be destroyed: %v", instanceID, err)
+	}
+	return nil
+}